@@ -4,6 +4,8 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"math/big"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -20,14 +22,13 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	log "github.com/sirupsen/logrus"
 )
 
-const PASSWORD = "password"
-
 // At this inteval will refresh accountBalanceMap,
 // If balance changed, event will send out to any receiver.
 var AccountBalanceWatcherInterval = time.Second * 20
@@ -38,12 +39,44 @@ var ErrNotDirectory = errors.New("not valid directory")
 // address is not valid
 var ErrInvalidAddress = errors.New("invalid address")
 
+// insufficient balance across every address of an account to cover a send
+var ErrInsufficientFunds = errors.New("insufficient funds across account addresses")
+
+// seedPassphrase protects the HD master seed at rest; it must come from the
+// deployment, never a constant baked into the binary.
+var ErrSeedPassphraseRequired = errors.New("seed passphrase must not be empty")
+
+// pendingAssociation is a hardware-wallet address waiting on /wallets/confirm
+// to prove, via an on-device signature, that its holder approved being
+// associated with account.
+type pendingAssociation struct {
+	Account string
+	Address common.Address
+}
+
 type EthAccount struct {
 	account string  `json:"account"`
 	address string  `json:"address"`
 	balance float64 `json:"balance"`
 }
 
+// accountRecord is the persisted state for a single keeper account. HD-managed
+// accounts derive every address from the wallet's master seed at
+// m/44'/60'/<Index>'/0/<0..NextAddressIndex-1>; accounts associated with a
+// hardware wallet (see /wallets/associate) hold a single externally-derived
+// address and never grow NextAddressIndex.
+type accountRecord struct {
+	Index            uint32   `json:"index"`
+	HDManaged        bool     `json:"hdManaged"`
+	NextAddressIndex uint32   `json:"nextAddressIndex"`
+	Addresses        []string `json:"addresses"`
+
+	// PassphraseCheck is account's passphrase, scrypt-encrypted around a
+	// known plaintext so UnlockAccount can verify a candidate passphrase
+	// without ever persisting it.
+	PassphraseCheck keystore.CryptoJSON `json:"passphraseCheck"`
+}
+
 type Client struct {
 	l *log.Logger
 
@@ -57,12 +90,54 @@ type Client struct {
 	// keystore
 	store *keystore.KeyStore
 
+	// manager routes signing requests to whichever WalletBackend (the
+	// HD wallet, on-disk keystore, or a Ledger over USB) owns the
+	// from-address.
+	manager *Manager
+
+	// hdWallet holds the encrypted master seed every keeper account is
+	// derived from; hdBackend exposes it through the WalletBackend interface.
+	hdWallet  *hdWallet
+	hdBackend *hdWalletBackend
+
+	// pendingConfirmations holds derived hardware-wallet addresses that
+	// have been associated with an account name but not yet confirmed
+	// with an on-device signature, keyed by address. The account only
+	// becomes usable once /wallets/confirm verifies that signature.
+	pendingConfirmations map[string]pendingAssociation
+	pendingConfirmLock   sync.Mutex
+
 	accountFilePath string
 	// account/address map lock, since ethereum doesn't support account
 	// we should have our own account/address map internally.
 	// only with this map we can provide services for the upstream services.
-	accountAddressMap  map[string]string
-	accountAddressLock sync.Mutex
+	accountMap  map[string]*accountRecord
+	accountLock sync.Mutex
+
+	// nextAccountIndex is the HD account index CreateAccount hands out next.
+	nextAccountIndex uint32
+
+	// nonceManager hands out nonces per from-address and resubmits
+	// transactions that have been pending too long.
+	nonceManager *NonceManager
+
+	// registered ERC-20 tokens, keyed by symbol, persisted next to accountFilePath.
+	tokenFilePath string
+	tokens        map[string]Token
+	tokensLock    sync.Mutex
+
+	// last-seen token balance per "account:symbol", used by
+	// accountBalanceWatcher to detect changes worth notifying on.
+	tokenBalanceMap  map[string]float64
+	tokenBalanceLock sync.Mutex
+
+	// subscriptions drives balance updates off newHeads/logs over
+	// websocket when available, falling back to accountBalanceWatcher's
+	// ticker loop otherwise.
+	subscriptions *SubscriptionManager
+
+	// unlocked tracks which accounts currently have signing access; see unlock.go.
+	unlocked *unlockCache
 
 	// account/balance map
 	accountBalanceMap  map[string]float64
@@ -71,19 +146,27 @@ type Client struct {
 	noti *notifier.Notifier
 }
 
-func NewClient(host, walletDir, accountFilePath, logDir string) (*Client, error) {
+// NewClient starts a Client against host, persisting wallet state under
+// walletDir and the account map at accountFilePath. seedPassphrase encrypts
+// the HD master seed at rest and must be supplied by the deployment (e.g.
+// from a secrets manager or env var) - it is never generated or defaulted.
+func NewClient(host, walletDir, accountFilePath, logDir, seedPassphrase string) (*Client, error) {
+	if seedPassphrase == "" {
+		return nil, ErrSeedPassphraseRequired
+	}
+
 	client := &Client{
-		walletDir:          walletDir,
-		accountFilePath:    accountFilePath,
-		accountAddressMap:  make(map[string]string),
-		accountAddressLock: sync.Mutex{},
+		walletDir:       walletDir,
+		accountFilePath: accountFilePath,
+		accountMap:      make(map[string]*accountRecord),
+		accountLock:     sync.Mutex{},
 
 		accountBalanceMap:  make(map[string]float64),
 		accountBalanceLock: sync.Mutex{},
 		noti:               notifier.New(),
 	}
 
-	// accountAddressMap initialization
+	// accountMap initialization
 	stat, err := os.Stat(client.accountFilePath)
 	if err != nil {
 		return nil, err
@@ -109,6 +192,44 @@ func NewClient(host, walletDir, accountFilePath, logDir string) (*Client, error)
 	}
 	client.store = keystore.NewKeyStore(walletDir, keystore.StandardScryptN, keystore.StandardScryptP)
 
+	client.pendingConfirmations = make(map[string]pendingAssociation)
+
+	// hd wallet initialization: the master seed lives encrypted alongside
+	// the keystore, and every keeper account derives its addresses from it.
+	seedFilePath := filepath.Join(walletDir, "seed.json")
+	client.hdWallet, err = newHDWallet(seedFilePath, seedPassphrase)
+	if err != nil {
+		return nil, err
+	}
+	client.hdBackend = newHDWalletBackend(client.hdWallet)
+
+	// re-derive every persisted account's addresses so hdBackend can sign for them.
+	for _, record := range client.accountMap {
+		if !record.HDManaged {
+			continue
+		}
+
+		for addressIndex := uint32(0); addressIndex < record.NextAddressIndex; addressIndex++ {
+			if _, err := client.hdBackend.derive(record.Index, addressIndex); err != nil {
+				return nil, err
+			}
+		}
+
+		if record.Index+1 > client.nextAccountIndex {
+			client.nextAccountIndex = record.Index + 1
+		}
+	}
+
+	// wallet backends: the HD wallet and on-disk keystore are always
+	// available; a Ledger backend is registered on a best-effort basis
+	// since a hardware wallet won't always be plugged in.
+	client.manager = NewManager(client.hdBackend, newKeystoreBackend(client.store))
+	if ledger, err := newLedgerBackend(); err == nil {
+		client.manager.Register(ledger)
+	} else {
+		log.Debugf("[NewClient] ledger backend unavailable: %s", err)
+	}
+
 	// rpcClient initialization
 	client.ethRpcClient, err = rpc.Dial(host)
 	if err != nil {
@@ -127,16 +248,36 @@ func NewClient(host, walletDir, accountFilePath, logDir string) (*Client, error)
 		Formatter: new(log.JSONFormatter),
 	}
 
-	for account, address := range client.accountAddressMap {
-		balance, err := client.getBalance(address)
+	for account, record := range client.accountMap {
+		balance, err := client.aggregateBalance(record.Addresses)
 		if err != nil {
 			log.Debug(err)
 		}
 		client.accountBalanceMap[account] = balance
 	}
 
+	journalPath := filepath.Join(walletDir, "nonce-journal.json")
+	client.nonceManager, err = newNonceManager(client, journalPath)
+	if err != nil {
+		return nil, err
+	}
+
+	// token registry initialization
+	client.tokenFilePath = filepath.Join(filepath.Dir(accountFilePath), "tokens.json")
+	client.tokenBalanceMap = make(map[string]float64)
+	if err := client.loadTokens(); err != nil {
+		return nil, err
+	}
+
+	client.unlocked = newUnlockCache(client.onAccountLocked)
+
 	go client.noti.Start()
-	go client.accountBalanceWatcher()
+
+	client.subscriptions = newSubscriptionManager(client)
+	client.subscriptions.Start(host)
+
+	go client.nonceManager.Watch()
+	go client.unlocked.evictExpired()
 
 	return client, nil
 }
@@ -162,58 +303,164 @@ func (client *Client) GetBlockCount() (int64, error) {
 	return height.Int64(), nil
 }
 
-// GetAddress - default address
+// GetAddress - default (first derived) address
 func (client *Client) GetAddress(account string) (string, error) {
-	address, ok := client.accountAddressMap[account]
-	if !ok {
+	client.accountLock.Lock()
+	record, ok := client.accountMap[account]
+	client.accountLock.Unlock()
+
+	if !ok || len(record.Addresses) == 0 {
 		return "", keeper.ErrAccountNotFound
 	}
 
-	return address, nil
+	return record.Addresses[0], nil
 }
 
-// Create Account
-func (client *Client) CreateAccount(account string) (keeper.Account, error) {
-	address, _ := client.GetAddress(account)
-	if len(address) > 0 {
-		return keeper.Account{}, keeper.ErrAccountExists
+// CreateAccount derives the first external-chain address for a new HD
+// account at m/44'/60'/<accountIndex>'/0/0 and persists the mapping. If
+// passphrase is empty one is generated; either way it is returned once
+// and never persisted, only a scrypt-encrypted check value is.
+func (client *Client) CreateAccount(account, passphrase string) (keeper.Account, string, error) {
+	client.accountLock.Lock()
+	defer client.accountLock.Unlock()
+
+	if _, exists := client.accountMap[account]; exists {
+		return keeper.Account{}, "", keeper.ErrAccountExists
 	}
 
-	acc, err := client.store.NewAccount(PASSWORD)
+	if passphrase == "" {
+		var err error
+		passphrase, err = generatePassphrase()
+		if err != nil {
+			return keeper.Account{}, "", err
+		}
+	}
+
+	accountIndex := client.nextAccountIndex
+	address, err := client.hdBackend.derive(accountIndex, 0)
 	if err != nil {
-		return keeper.Account{}, err
+		return keeper.Account{}, "", err
 	}
+	client.nextAccountIndex++
 
-	client.accountAddressLock.Lock()
-	client.accountAddressMap[account] = acc.Address.Hex()
-	client.accountAddressLock.Unlock()
+	passphraseCheck, err := keystore.EncryptDataV3([]byte(account), []byte(passphrase), keystore.StandardScryptN, keystore.StandardScryptP)
+	if err != nil {
+		return keeper.Account{}, "", err
+	}
+
+	record := &accountRecord{
+		Index:            accountIndex,
+		HDManaged:        true,
+		NextAddressIndex: 1,
+		Addresses:        []string{address.Hex()},
+		PassphraseCheck:  passphraseCheck,
+	}
+	client.accountMap[account] = record
 
 	client.accountBalanceLock.Lock()
 	client.accountBalanceMap[account] = 0
 	client.accountBalanceLock.Unlock()
 
-	err = client.persistAccountMap()
-	if err != nil {
-		return keeper.Account{}, err
+	if err := client.persistAccountMap(); err != nil {
+		return keeper.Account{}, "", err
+	}
+
+	// Refresh re-reads the account map under accountLock, which is held
+	// until this function returns, so it runs in its own goroutine.
+	if client.subscriptions != nil {
+		go client.subscriptions.Refresh()
 	}
 
 	return keeper.Account{
-		Account: account,
-		Balance: 0,
-		Addresses: []string{
-			acc.Address.Hex(),
-		},
-	}, nil
+		Account:   account,
+		Balance:   0,
+		Addresses: record.Addresses,
+	}, passphrase, nil
+}
+
+// UnlockAccount verifies passphrase against account's stored check value
+// and, if it matches, derives and caches account's signing keys in hdBackend
+// for timeout (DefaultUnlockTimeout if <= 0). Hardware-wallet-backed
+// accounts aren't unlocked this way; they gate signing on the device itself.
+func (client *Client) UnlockAccount(account, passphrase string, timeout time.Duration) error {
+	client.accountLock.Lock()
+	record, found := client.accountMap[account]
+	client.accountLock.Unlock()
+
+	if !found {
+		return keeper.ErrAccountNotFound
+	}
+
+	if !record.HDManaged {
+		return keeper.ErrNotSupport
+	}
+
+	if _, err := keystore.DecryptDataV3(record.PassphraseCheck, passphrase); err != nil {
+		return ErrWrongPassphrase
+	}
+
+	if timeout <= 0 {
+		timeout = DefaultUnlockTimeout
+	}
+
+	if err := client.hdBackend.unlockAddresses(hexAddressesToCommon(record.Addresses)); err != nil {
+		return err
+	}
+
+	client.unlocked.unlock(account, timeout)
+	return nil
+}
+
+// LockAccount immediately revokes account's signing access and zeroes its
+// cached signing keys.
+func (client *Client) LockAccount(account string) {
+	client.unlocked.lockAccount(account)
 }
 
-// GetAccountInfo
+// onAccountLocked evicts account's derived signing keys from hdBackend; it
+// is unlockCache's eviction hook, so both manual locking and timeout expiry
+// actually destroy key material rather than just flipping a flag.
+func (client *Client) onAccountLocked(account string) {
+	client.accountLock.Lock()
+	record, found := client.accountMap[account]
+	client.accountLock.Unlock()
+
+	if !found {
+		return
+	}
+
+	client.hdBackend.lockAddresses(hexAddressesToCommon(record.Addresses))
+}
+
+// hexAddressesToCommon converts a slice of hex address strings into
+// common.Address values.
+func hexAddressesToCommon(hexAddresses []string) []common.Address {
+	out := make([]common.Address, 0, len(hexAddresses))
+	for _, hexAddress := range hexAddresses {
+		out = append(out, common.HexToAddress(hexAddress))
+	}
+
+	return out
+}
+
+// GetAccountInfo aggregates the balance across every address derived for account.
 func (client *Client) GetAccountInfo(account string, minConf int) (keeper.Account, error) {
-	address, found := client.accountAddressMap[account]
+	client.accountLock.Lock()
+	record, found := client.accountMap[account]
+	var addresses []string
+	if found {
+		// copied while still holding the lock: record.Addresses can be
+		// reassigned by a concurrent GetNewAddress's append, and reading
+		// the slice header after unlocking would race with that.
+		addresses = append([]string(nil), record.Addresses...)
+	}
+	client.accountLock.Unlock()
+
 	if !found {
 		return keeper.Account{}, keeper.ErrAccountNotFound
 	}
 
-	balance, err := client.getBalance(address)
+	balance, err := client.aggregateBalance(addresses)
 	if err != nil {
 		return keeper.Account{}, err
 	}
@@ -221,39 +468,85 @@ func (client *Client) GetAccountInfo(account string, minConf int) (keeper.Accoun
 	return keeper.Account{
 		Account:   account,
 		Balance:   balance,
-		Addresses: []string{address},
+		Addresses: addresses,
 	}, nil
 }
 
+// GetNewAddress derives and returns the next unused external-chain
+// address for account, under m/44'/60'/<accountIndex>'/0/<i>.
 func (client *Client) GetNewAddress(account string) (string, error) {
-	return "", keeper.ErrNotSupport
+	client.accountLock.Lock()
+	defer client.accountLock.Unlock()
+
+	record, found := client.accountMap[account]
+	if !found {
+		return "", keeper.ErrAccountNotFound
+	}
+
+	if !record.HDManaged {
+		return "", keeper.ErrNotSupport
+	}
+
+	address, err := client.hdBackend.derive(record.Index, record.NextAddressIndex)
+	if err != nil {
+		return "", err
+	}
+
+	record.NextAddressIndex++
+	record.Addresses = append(record.Addresses, address.Hex())
+
+	if err := client.persistAccountMap(); err != nil {
+		return "", err
+	}
+
+	// account may already be unlocked; extend that unlock to the address
+	// just derived so it isn't stranded without a cached signing key.
+	if client.unlocked.isUnlocked(account) {
+		if err := client.hdBackend.unlockAddresses([]common.Address{address}); err != nil {
+			return "", err
+		}
+	}
+
+	// Refresh re-reads the account map under accountLock, which is held
+	// until this function returns, so it runs in its own goroutine.
+	if client.subscriptions != nil {
+		go client.subscriptions.Refresh()
+	}
+
+	return address.Hex(), nil
 }
 
 // GetAddressesByAccount
 func (client *Client) GetAddressesByAccount(account string) ([]string, error) {
-	address, ok := client.accountAddressMap[account]
+	client.accountLock.Lock()
+	record, ok := client.accountMap[account]
+	client.accountLock.Unlock()
+
 	if !ok {
 		return []string{}, keeper.ErrAccountNotFound
 	}
 
-	return []string{address}, nil
+	return record.Addresses, nil
 }
 
 // ListAccountsMinConf
 func (client *Client) ListAccountsMinConf(conf int) (map[string]float64, error) {
-	accounts := make(map[string]float64, len(client.accountAddressMap))
-	for name, address := range client.accountAddressMap {
-		balance, err := client.getBalance(address)
+	client.accountLock.Lock()
+	defer client.accountLock.Unlock()
+
+	balances := make(map[string]float64, len(client.accountMap))
+	for name, record := range client.accountMap {
+		balance, err := client.aggregateBalance(record.Addresses)
 		if err != nil {
 			client.l.Errorf("[ListAccountsMinConf] %s", err)
 
-			accounts[name] = 0
+			balances[name] = 0
 		} else {
-			accounts[name] = balance
+			balances[name] = balance
 		}
 	}
 
-	return accounts, nil
+	return balances, nil
 }
 
 // SendToAddress
@@ -261,65 +554,134 @@ func (client *Client) SendToAddress(address string, amount float64) error {
 	return keeper.ErrNotSupport
 }
 
-// TODO check validity of account and have sufficent balance
+// SendFrom sends amount to hexToAddress, funding it from account's derived
+// addresses greedily: addresses are drained in derivation order until the
+// full amount is covered, each draw producing its own signed transaction.
+// account may also be a raw hex address, in which case it is used directly.
 func (client *Client) SendFrom(account, hexToAddress string, amount float64) error {
-	hexFromAddress := account
-	if !common.IsHexAddress(account) {
-		hexFromAddress, found := client.accountAddressMap[account]
-		_ = hexFromAddress
+	if !common.IsHexAddress(hexToAddress) {
+		return ErrInvalidAddress
+	}
+	toAddress := common.HexToAddress(hexToAddress)
+
+	var addresses []string
+	if common.IsHexAddress(account) {
+		addresses = []string{account}
+	} else {
+		client.accountLock.Lock()
+		record, found := client.accountMap[account]
+		client.accountLock.Unlock()
+
 		if !found {
-			return ErrInvalidAddress
+			return keeper.ErrAccountNotFound
+		}
+
+		// hardware-wallet-backed accounts gate signing on the device
+		// itself; only HD-managed accounts need an unlocked passphrase.
+		if record.HDManaged && !client.unlocked.isUnlocked(account) {
+			return ErrAccountLocked
 		}
+
+		addresses = record.Addresses
 	}
 
-	if !common.IsHexAddress(hexFromAddress) {
-		return ErrInvalidAddress
+	gasPrice, err := client.SuggestGasPrice(context.Background())
+	if err != nil {
+		return err
 	}
+	gasReserve, _ := weiToEther(new(big.Int).Mul(gasPrice, big.NewInt(21000))).Float64()
 
-	if !common.IsHexAddress(hexToAddress) {
-		return ErrInvalidAddress
+	remaining := amount
+	for _, hexFromAddress := range addresses {
+		if remaining <= 0 {
+			break
+		}
+
+		balance, err := client.getBalance(hexFromAddress)
+		if err != nil {
+			log.Error(err)
+			return err
+		}
+
+		// reserve enough to cover this address's own gas cost, or a
+		// full-balance draw would leave it short and the send would be
+		// rejected by the node for insufficient funds.
+		available := balance - gasReserve
+		if available <= 0 {
+			continue
+		}
+
+		draw := available
+		if draw > remaining {
+			draw = remaining
+		}
+
+		if err := client.sendFromAddress(hexFromAddress, toAddress, draw, gasPrice); err != nil {
+			log.Error(err)
+			return err
+		}
+
+		remaining -= draw
+	}
+
+	if remaining > 0 {
+		return ErrInsufficientFunds
 	}
 
+	return nil
+}
+
+// sendFromAddress signs and broadcasts a single transfer from hexFromAddress
+// at gasPrice, registering the nonce it used with the NonceManager so the
+// transaction can be tracked and, if necessary, resubmitted. gasPrice is
+// passed in rather than re-fetched so it always matches the reserve SendFrom
+// computed its draw amounts against.
+func (client *Client) sendFromAddress(hexFromAddress string, toAddress common.Address, amount float64, gasPrice *big.Int) error {
+	if !common.IsHexAddress(hexFromAddress) {
+		return ErrInvalidAddress
+	}
 	fromAddress := common.HexToAddress(hexFromAddress)
-	toAddress := common.HexToAddress(hexToAddress)
 
-	nonce, err := client.PendingNonceAt(context.Background(), fromAddress)
+	nonce, err := client.nonceManager.NextNonce(context.Background(), fromAddress)
 	if err != nil {
-		log.Error(err)
 		return err
 	}
 
 	value := etherToWei(amount)
 	gasLimit := uint64(21000)
-	gasPrice, err := client.SuggestGasPrice(context.Background())
-	if err != nil {
-		log.Error(err)
-		return err
-	}
 
 	chainID, err := client.NetworkID(context.Background())
 	if err != nil {
-		log.Error(err)
 		return err
 	}
 
 	tx := types.NewTransaction(nonce, toAddress, value, gasLimit, gasPrice, []byte{})
-	accountStore := accounts.Account{Address: fromAddress}
-	signedTx, err := client.store.SignTx(accountStore, tx, chainID)
+	signedTx, err := client.manager.SignTx(fromAddress, tx, chainID)
 	if err != nil {
-		log.Error(err)
 		return err
 	}
 
-	err = client.SendTransaction(context.Background(), signedTx)
-	if err != nil {
-		log.Error(err)
+	if err := client.SendTransaction(context.Background(), signedTx); err != nil {
 		return err
 	}
 
+	client.nonceManager.Track(fromAddress, signedTx)
 	return nil
 }
 
+// signSelfTransfer builds and signs a zero-value transaction from address
+// to itself at a fixed nonce, used by the NonceManager's Cancel to replace
+// a stuck transaction with a no-op.
+func (client *Client) signSelfTransfer(address common.Address, nonce uint64, gasPrice *big.Int) (*types.Transaction, error) {
+	chainID, err := client.NetworkID(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	tx := types.NewTransaction(nonce, address, big.NewInt(0), 21000, gasPrice, []byte{})
+	return client.manager.SignTx(address, tx, chainID)
+}
+
 // ListUnspentMin
 func (client *Client) ListUnspentMin(minConf int) ([]btcjson.ListUnspentResult, error) {
 	return []btcjson.ListUnspentResult{}, keeper.ErrNotSupport
@@ -374,11 +736,341 @@ func (client *Client) AddRoutes(engine *gin.Engine) {
 			client.noti.UninstallReceiver(uninstallParams.Name)
 		}
 	})
+
+	txGroup := engine.Group("/tx")
+	// list every in-flight transaction the NonceManager is tracking
+	txGroup.GET("/pending", func(c *gin.Context) {
+		c.JSON(http.StatusOK, client.nonceManager.Pending())
+	})
+
+	// force an immediate gas-bumped resubmission of a pending transaction,
+	//  address - 0x...
+	//  nonce - 3
+	txGroup.POST("/resubmit", func(c *gin.Context) {
+		var resubmitParams struct {
+			Address string `json:"address"`
+			Nonce   uint64 `json:"nonce"`
+		}
+
+		if err := c.ShouldBind(&resubmitParams); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if !common.IsHexAddress(resubmitParams.Address) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": ErrInvalidAddress.Error()})
+			return
+		}
+
+		var record *pendingTx
+		address := common.HexToAddress(resubmitParams.Address)
+		for _, candidate := range client.nonceManager.Pending() {
+			if candidate.Address == address && candidate.Nonce == resubmitParams.Nonce {
+				record = candidate
+				break
+			}
+		}
+
+		if record == nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": ErrTxNotPending.Error()})
+			return
+		}
+
+		if err := client.nonceManager.resubmit(record); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"txHash": record.TxHash.Hex()})
+	})
+
+	// cancel a pending transaction by replacing it with a zero-value
+	// self-send at the same nonce and a bumped gas price.
+	txGroup.POST("/cancel", func(c *gin.Context) {
+		var cancelParams struct {
+			Address string `json:"address"`
+			Nonce   uint64 `json:"nonce"`
+		}
+
+		if err := c.ShouldBind(&cancelParams); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if !common.IsHexAddress(cancelParams.Address) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": ErrInvalidAddress.Error()})
+			return
+		}
+
+		txHash, err := client.nonceManager.Cancel(common.HexToAddress(cancelParams.Address), cancelParams.Nonce)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"txHash": txHash.Hex()})
+	})
+
+	walletGroup := engine.Group("/wallets")
+	// list every address exposed by a connected hardware wallet
+	walletGroup.GET("/list", func(c *gin.Context) {
+		wallets := []string{}
+		for _, backend := range client.manager.Backends() {
+			if _, ok := backend.(*ledgerBackend); !ok {
+				continue
+			}
+
+			for _, acc := range backend.Accounts() {
+				wallets = append(wallets, acc.Address.Hex())
+			}
+		}
+
+		c.JSON(http.StatusOK, wallets)
+	})
+
+	// derive a new address on the first connected Ledger at a BIP-44 path,
+	//  path - m/44'/60'/0'/0/0
+	walletGroup.POST("/derive", func(c *gin.Context) {
+		var deriveParams struct {
+			Path string `json:"path"`
+		}
+
+		if err := c.ShouldBind(&deriveParams); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		path, err := accounts.ParseDerivationPath(deriveParams.Path)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		var derived accounts.Account
+		var ledgerFound bool
+		for _, backend := range client.manager.Backends() {
+			ledger, ok := backend.(*ledgerBackend)
+			if !ok {
+				continue
+			}
+
+			ledgerFound = true
+			derived, err = ledger.Derive(path, true)
+			break
+		}
+
+		if !ledgerFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "no ledger device connected"})
+			return
+		}
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"address": derived.Address.Hex()})
+	})
+
+	// associate a derived hardware-wallet address with a keeper account name,
+	// pending confirmation via a signature on the device.
+	walletGroup.POST("/associate", func(c *gin.Context) {
+		var associateParams struct {
+			Account string `json:"account"`
+			Address string `json:"address"`
+		}
+
+		if err := c.ShouldBind(&associateParams); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if !common.IsHexAddress(associateParams.Address) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": ErrInvalidAddress.Error()})
+			return
+		}
+
+		address := common.HexToAddress(associateParams.Address)
+		backend, err := client.manager.Find(address)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+
+		if _, ok := backend.(*ledgerBackend); !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "address is not backed by a connected hardware wallet"})
+			return
+		}
+
+		// The account map isn't touched until /wallets/confirm verifies an
+		// on-device signature; until then the address isn't usable.
+		client.pendingConfirmLock.Lock()
+		client.pendingConfirmations[associateParams.Address] = pendingAssociation{
+			Account: associateParams.Account,
+			Address: address,
+		}
+		client.pendingConfirmLock.Unlock()
+
+		c.JSON(http.StatusOK, gin.H{"status": "pending confirmation"})
+	})
+
+	// confirm a pending association by asking the device holding address to
+	// sign a challenge message, proving the holder approved it. Only after
+	// that signature recovers to address is the account actually created.
+	walletGroup.POST("/confirm", func(c *gin.Context) {
+		var confirmParams struct {
+			Address string `json:"address"`
+		}
+
+		if err := c.ShouldBind(&confirmParams); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		client.pendingConfirmLock.Lock()
+		pending, found := client.pendingConfirmations[confirmParams.Address]
+		client.pendingConfirmLock.Unlock()
+
+		if !found {
+			c.JSON(http.StatusNotFound, gin.H{"error": "no pending confirmation for this address"})
+			return
+		}
+
+		backend, err := client.manager.Find(pending.Address)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+
+		ledger, ok := backend.(*ledgerBackend)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "address is not backed by a connected hardware wallet"})
+			return
+		}
+
+		challenge := []byte(fmt.Sprintf("wallet-keeper: confirm %s for account %s", pending.Address.Hex(), pending.Account))
+		sig, err := ledger.SignChallenge(pending.Address, challenge)
+		if err != nil {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+
+		recovered, err := recoverTextSigner(challenge, sig)
+		if err != nil || recovered != pending.Address {
+			c.JSON(http.StatusForbidden, gin.H{"error": "on-device signature did not match the associated address"})
+			return
+		}
+
+		client.pendingConfirmLock.Lock()
+		delete(client.pendingConfirmations, confirmParams.Address)
+		client.pendingConfirmLock.Unlock()
+
+		client.accountLock.Lock()
+		client.accountMap[pending.Account] = &accountRecord{
+			HDManaged: false,
+			Addresses: []string{pending.Address.Hex()},
+		}
+		client.accountLock.Unlock()
+
+		client.accountBalanceLock.Lock()
+		client.accountBalanceMap[pending.Account] = 0
+		client.accountBalanceLock.Unlock()
+
+		if err := client.persistAccountMap(); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		if client.subscriptions != nil {
+			client.subscriptions.Refresh()
+		}
+
+		c.JSON(http.StatusOK, gin.H{"status": "confirmed"})
+	})
+
+	tokenGroup := engine.Group("/tokens")
+	// register an ERC-20 contract by symbol/address; decimals() is read from the contract.
+	tokenGroup.POST("/register", func(c *gin.Context) {
+		var registerParams struct {
+			Symbol  string `json:"symbol"`
+			Address string `json:"address"`
+		}
+
+		if err := c.ShouldBind(&registerParams); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		token, err := client.RegisterToken(registerParams.Symbol, registerParams.Address)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, token)
+	})
+
+	tokenGroup.GET("/list", func(c *gin.Context) {
+		c.JSON(http.StatusOK, client.ListTokens())
+	})
+
+	tokenGroup.GET("/balance/:account", func(c *gin.Context) {
+		balances, err := client.ListTokenBalances(c.Param("account"))
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, balances)
+	})
+
+	subscriptionGroup := engine.Group("/subscriptions")
+	subscriptionGroup.GET("/status", func(c *gin.Context) {
+		c.JSON(http.StatusOK, client.subscriptions.Status())
+	})
+
+	accountGroup := engine.Group("/accounts")
+	// unlock an account's signing key for timeoutSeconds (DefaultUnlockTimeout if omitted).
+	accountGroup.POST("/unlock", func(c *gin.Context) {
+		var unlockParams struct {
+			Account        string `json:"account"`
+			Passphrase     string `json:"passphrase"`
+			TimeoutSeconds int64  `json:"timeoutSeconds"`
+		}
+
+		if err := c.ShouldBind(&unlockParams); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		timeout := time.Duration(unlockParams.TimeoutSeconds) * time.Second
+		if err := client.UnlockAccount(unlockParams.Account, unlockParams.Passphrase, timeout); err != nil {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"status": "unlocked"})
+	})
+
+	accountGroup.POST("/lock", func(c *gin.Context) {
+		var lockParams struct {
+			Account string `json:"account"`
+		}
+
+		if err := c.ShouldBind(&lockParams); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		client.LockAccount(lockParams.Account)
+		c.JSON(http.StatusOK, gin.H{"status": "locked"})
+	})
+
 	return
 }
 
-// persistAccountMap write `accountAddressMap` into file `client.accountAddressMap`,
-// `accountAddressMap` will persist into file with json format,
+// persistAccountMap writes `accountMap` into file `client.accountFilePath`
+// as json.
 //
 // Error - return if `client.accountFilePath` not found or write permission not right.
 func (client *Client) persistAccountMap() error {
@@ -391,25 +1083,25 @@ func (client *Client) persistAccountMap() error {
 		return ErrNotValidAccountFile
 	}
 
-	file, err := os.OpenFile(client.accountFilePath, os.O_WRONLY, 0755)
+	file, err := os.OpenFile(client.accountFilePath, os.O_WRONLY|os.O_TRUNC, 0755)
 	if err != nil {
 		return err
 	}
 	defer file.Close()
 
-	return json.NewEncoder(file).Encode(client.accountAddressMap)
+	return json.NewEncoder(file).Encode(client.accountMap)
 }
 
 // loadAccountMap from filesystem.
 func (client *Client) loadAccountMap() error {
-	client.accountAddressMap = make(map[string]string)
+	client.accountMap = make(map[string]*accountRecord)
 	file, err := os.Open(client.accountFilePath)
 	if err != nil {
 		return err
 	}
 	defer file.Close()
 
-	err = json.NewDecoder(file).Decode(&client.accountAddressMap)
+	err = json.NewDecoder(file).Decode(&client.accountMap)
 	if err != nil {
 		return err
 	}
@@ -429,31 +1121,137 @@ func (client *Client) getBalance(address string) (float64, error) {
 	return float64Value, nil
 }
 
+// aggregateBalance sums the balance across every address of an account.
+func (client *Client) aggregateBalance(addresses []string) (float64, error) {
+	var total float64
+	for _, address := range addresses {
+		balance, err := client.getBalance(address)
+		if err != nil {
+			return total, err
+		}
+
+		total += balance
+	}
+
+	return total, nil
+}
+
+// trackedAddresses returns every address across every account, as the
+// left-padded topic hashes the "logs" subscription filters on.
+func (client *Client) trackedAddresses() []common.Hash {
+	client.accountLock.Lock()
+	defer client.accountLock.Unlock()
+
+	topics := make([]common.Hash, 0)
+	for _, record := range client.accountMap {
+		for _, address := range record.Addresses {
+			topics = append(topics, common.BytesToHash(common.HexToAddress(address).Bytes()))
+		}
+	}
+
+	return topics
+}
+
+// recoverTextSigner recovers the address that produced sig over message via
+// the same personal-message hash go-ethereum's Wallet.SignText uses.
+func recoverTextSigner(message, sig []byte) (common.Address, error) {
+	pub, err := crypto.SigToPub(accounts.TextHash(message), sig)
+	if err != nil {
+		return common.Address{}, err
+	}
+
+	return crypto.PubkeyToAddress(*pub), nil
+}
+
+// tokenBySymbolAddress returns the registered token at address, if any.
+func (client *Client) tokenBySymbolAddress(address common.Address) *Token {
+	for _, token := range client.ListTokens() {
+		if token.Address == address {
+			return &token
+		}
+	}
+
+	return nil
+}
+
+// accountOwning returns the account name and record that own hexAddress.
+func (client *Client) accountOwning(hexAddress string) (string, *accountRecord) {
+	client.accountLock.Lock()
+	defer client.accountLock.Unlock()
+
+	for account, record := range client.accountMap {
+		for _, address := range record.Addresses {
+			if strings.EqualFold(address, hexAddress) {
+				return account, record
+			}
+		}
+	}
+
+	return "", nil
+}
+
 func (client *Client) accountBalanceWatcher() {
 	ticker := time.NewTicker(AccountBalanceWatcherInterval)
 
 	refreshFunc := func() {
-		for account, balance := range client.accountBalanceMap {
-			address, found := client.accountAddressMap[account]
-			if found {
-				newBalance, err := client.getBalance(address)
+		client.accountLock.Lock()
+		addressesByAccount := make(map[string][]string, len(client.accountMap))
+		for account, record := range client.accountMap {
+			// copied while still holding the lock: record.Addresses can be
+			// reassigned by a concurrent GetNewAddress's append.
+			addressesByAccount[account] = append([]string(nil), record.Addresses...)
+		}
+		client.accountLock.Unlock()
+
+		for account, addresses := range addressesByAccount {
+			balance, found := client.accountBalanceMap[account]
+			if !found {
+				continue
+			}
+
+			newBalance, err := client.aggregateBalance(addresses)
+			if err != nil {
+				log.Println(err)
+			}
+
+			// balance updated
+			if balance != newBalance {
+				event := notifier.NewEthBalanceChangeEvent(map[string]interface{}{
+					"account":    account,
+					"addresses":  addresses,
+					"newBalance": newBalance,
+					"balance":    balance,
+				})
+				client.noti.EventChan() <- event
+
+				client.accountBalanceLock.Lock()
+				client.accountBalanceMap[account] = newBalance
+				client.accountBalanceLock.Unlock()
+			}
+
+			for _, token := range client.ListTokens() {
+				key := account + ":" + token.Symbol
+
+				newBalance, err := client.aggregateTokenBalance(token, addresses)
 				if err != nil {
 					log.Println(err)
+					continue
 				}
 
-				// balance updated
-				if balance != newBalance {
-					event := notifier.NewEthBalanceChangeEvent(map[string]interface{}{
+				client.tokenBalanceLock.Lock()
+				balance, found := client.tokenBalanceMap[key]
+				client.tokenBalanceMap[key] = newBalance
+				client.tokenBalanceLock.Unlock()
+
+				if found && balance != newBalance {
+					event := notifier.NewEthTokenBalanceChangeEvent(map[string]interface{}{
 						"account":    account,
-						"address":    address,
+						"symbol":     token.Symbol,
+						"addresses":  addresses,
 						"newBalance": newBalance,
 						"balance":    balance,
 					})
 					client.noti.EventChan() <- event
-
-					client.accountBalanceLock.Lock()
-					client.accountBalanceMap[account] = newBalance
-					client.accountBalanceLock.Unlock()
 				}
 			}
 		}
@@ -0,0 +1,291 @@
+package eth
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"os"
+	"sync"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcutil/hdkeychain"
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// hdCoinType is ethereum's registered SLIP-44 coin type, used as the
+// second component of every derivation path this wallet produces:
+// m/44'/60'/<accountIndex>'/0/<addressIndex>.
+const hdCoinType = uint32(60)
+
+var ErrSeedNotUnlocked = errors.New("hd wallet seed is not unlocked")
+
+// hdWallet derives ethereum keys from a single BIP-32 master seed, kept
+// encrypted at rest with the same scrypt parameters the keystore uses
+// for individual keys.
+type hdWallet struct {
+	seedFilePath string
+	master       *hdkeychain.ExtendedKey
+	lock         sync.Mutex
+}
+
+type encryptedSeed struct {
+	Crypto keystore.CryptoJSON `json:"crypto"`
+}
+
+// newHDWallet loads the encrypted seed at seedFilePath, generating and
+// persisting a new one (encrypted with passphrase) the first time it's called.
+func newHDWallet(seedFilePath, passphrase string) (*hdWallet, error) {
+	w := &hdWallet{seedFilePath: seedFilePath}
+
+	if _, err := os.Stat(seedFilePath); os.IsNotExist(err) {
+		seed, err := hdkeychain.GenerateSeed(hdkeychain.RecommendedSeedLen)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := w.persist(seed, passphrase); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := w.unlock(passphrase); err != nil {
+		return nil, err
+	}
+
+	return w, nil
+}
+
+func (w *hdWallet) persist(seed []byte, passphrase string) error {
+	cryptoJSON, err := keystore.EncryptDataV3(seed, []byte(passphrase), keystore.StandardScryptN, keystore.StandardScryptP)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(w.seedFilePath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return json.NewEncoder(file).Encode(encryptedSeed{Crypto: cryptoJSON})
+}
+
+func (w *hdWallet) unlock(passphrase string) error {
+	file, err := os.Open(w.seedFilePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var stored encryptedSeed
+	if err := json.NewDecoder(file).Decode(&stored); err != nil {
+		return err
+	}
+
+	seed, err := keystore.DecryptDataV3(stored.Crypto, passphrase)
+	if err != nil {
+		return err
+	}
+
+	master, err := hdkeychain.NewMaster(seed, &chaincfg.MainNetParams)
+	if err != nil {
+		return err
+	}
+
+	w.lock.Lock()
+	w.master = master
+	w.lock.Unlock()
+
+	return nil
+}
+
+// privateKeyAt derives the private key at m/44'/60'/accountIndex'/0/addressIndex.
+func (w *hdWallet) privateKeyAt(accountIndex, addressIndex uint32) (*ecdsa.PrivateKey, error) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.master == nil {
+		return nil, ErrSeedNotUnlocked
+	}
+
+	purpose, err := w.master.Child(hdkeychain.HardenedKeyStart + 44)
+	if err != nil {
+		return nil, err
+	}
+
+	coinType, err := purpose.Child(hdkeychain.HardenedKeyStart + hdCoinType)
+	if err != nil {
+		return nil, err
+	}
+
+	account, err := coinType.Child(hdkeychain.HardenedKeyStart + accountIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	external, err := account.Child(0)
+	if err != nil {
+		return nil, err
+	}
+
+	addressKey, err := external.Child(addressIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	ecPrivKey, err := addressKey.ECPrivKey()
+	if err != nil {
+		return nil, err
+	}
+
+	return crypto.ToECDSA(ecPrivKey.Serialize())
+}
+
+func (w *hdWallet) addressAt(accountIndex, addressIndex uint32) (common.Address, error) {
+	priv, err := w.privateKeyAt(accountIndex, addressIndex)
+	if err != nil {
+		return common.Address{}, err
+	}
+
+	return crypto.PubkeyToAddress(priv.PublicKey), nil
+}
+
+// hdWalletBackend exposes the hdWallet's derived addresses through the
+// WalletBackend interface, so Manager can route SendFrom to it exactly
+// like it does for the keystore and Ledger backends.
+type hdWalletBackend struct {
+	wallet *hdWallet
+
+	// known maps a derived address to the (accountIndex, addressIndex)
+	// pair it was derived from. Addresses are public, so this is populated
+	// regardless of lock state.
+	known map[common.Address][2]uint32
+	lock  sync.Mutex
+
+	// unlockedKeys holds the actual derived private key for every address
+	// whose account is currently unlocked. SignTx refuses to sign for an
+	// address missing here, so unlocking - not just an API-level flag - is
+	// what makes key material come into existence.
+	unlockedKeys map[common.Address]*ecdsa.PrivateKey
+	unlockedLock sync.Mutex
+}
+
+func newHDWalletBackend(wallet *hdWallet) *hdWalletBackend {
+	return &hdWalletBackend{
+		wallet:       wallet,
+		known:        make(map[common.Address][2]uint32),
+		unlockedKeys: make(map[common.Address]*ecdsa.PrivateKey),
+	}
+}
+
+// unlockAddresses derives and caches the signing key for each of addresses,
+// making SignTx succeed for them until lockAddresses or eviction clears it.
+func (b *hdWalletBackend) unlockAddresses(addresses []common.Address) error {
+	b.unlockedLock.Lock()
+	defer b.unlockedLock.Unlock()
+
+	for _, address := range addresses {
+		b.lock.Lock()
+		indices, found := b.known[address]
+		b.lock.Unlock()
+
+		if !found {
+			continue
+		}
+
+		priv, err := b.wallet.privateKeyAt(indices[0], indices[1])
+		if err != nil {
+			return err
+		}
+
+		b.unlockedKeys[address] = priv
+	}
+
+	return nil
+}
+
+// lockAddresses zeroes and evicts the cached signing key for each of addresses.
+func (b *hdWalletBackend) lockAddresses(addresses []common.Address) {
+	b.unlockedLock.Lock()
+	defer b.unlockedLock.Unlock()
+
+	for _, address := range addresses {
+		if priv, found := b.unlockedKeys[address]; found {
+			priv.D.SetInt64(0)
+			delete(b.unlockedKeys, address)
+		}
+	}
+}
+
+// derive derives and remembers the address at (accountIndex, addressIndex).
+func (b *hdWalletBackend) derive(accountIndex, addressIndex uint32) (common.Address, error) {
+	address, err := b.wallet.addressAt(accountIndex, addressIndex)
+	if err != nil {
+		return common.Address{}, err
+	}
+
+	b.lock.Lock()
+	b.known[address] = [2]uint32{accountIndex, addressIndex}
+	b.lock.Unlock()
+
+	return address, nil
+}
+
+func (b *hdWalletBackend) Accounts() []accounts.Account {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	out := make([]accounts.Account, 0, len(b.known))
+	for addr := range b.known {
+		out = append(out, accounts.Account{Address: addr})
+	}
+
+	return out
+}
+
+func (b *hdWalletBackend) Open(passphrase string) error {
+	return b.wallet.unlock(passphrase)
+}
+
+func (b *hdWalletBackend) Close() error {
+	return nil
+}
+
+// Derive accepts only paths of the shape m/44'/60'/<accountIndex>'/0/<addressIndex>.
+func (b *hdWalletBackend) Derive(path accounts.DerivationPath, pin bool) (accounts.Account, error) {
+	if len(path) != 5 ||
+		path[0] != hdkeychain.HardenedKeyStart+44 ||
+		path[1] != hdkeychain.HardenedKeyStart+hdCoinType ||
+		path[2] < hdkeychain.HardenedKeyStart ||
+		path[3] != 0 {
+		return accounts.Account{}, errors.New("unsupported derivation path for hd wallet")
+	}
+
+	accountIndex := path[2] - hdkeychain.HardenedKeyStart
+	addressIndex := path[4]
+
+	address, err := b.derive(accountIndex, addressIndex)
+	if err != nil {
+		return accounts.Account{}, err
+	}
+
+	return accounts.Account{Address: address}, nil
+}
+
+func (b *hdWalletBackend) SignTx(addr common.Address, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	b.unlockedLock.Lock()
+	priv, found := b.unlockedKeys[addr]
+	b.unlockedLock.Unlock()
+
+	if !found {
+		return nil, ErrAccountLocked
+	}
+
+	signer := types.NewEIP155Signer(chainID)
+	return types.SignTx(tx, signer, priv)
+}
@@ -0,0 +1,381 @@
+package eth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/cmingxu/wallet-keeper/notifier"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	log "github.com/sirupsen/logrus"
+)
+
+// ResubmitThreshold is how long a transaction can sit pending before the
+// NonceManager bumps its gas price and re-broadcasts it.
+var ResubmitThreshold = time.Minute * 5
+
+// NonceManagerPollInterval is how often the background resubmission
+// goroutine checks on outstanding transactions.
+var NonceManagerPollInterval = time.Second * 30
+
+// GasBumpPercent is how much a stuck transaction's gas price is
+// increased by on each resubmission attempt.
+var GasBumpPercent = int64(10)
+
+var ErrTxNotPending = fmt.Errorf("no pending transaction for that nonce")
+
+// pendingTx is a single in-flight transaction tracked by the NonceManager,
+// journaled to disk so a restart can pick up where it left off. To/Value/Data/
+// GasLimit are kept so a stuck transaction can be resubmitted as itself
+// (same effect, bumped gas price) rather than replaced with something else.
+type pendingTx struct {
+	Address       common.Address `json:"address"`
+	Nonce         uint64         `json:"nonce"`
+	TxHash        common.Hash    `json:"txHash"`
+	To            common.Address `json:"to"`
+	Value         *big.Int       `json:"value"`
+	Data          []byte         `json:"data"`
+	GasLimit      uint64         `json:"gasLimit"`
+	GasPrice      *big.Int       `json:"gasPrice"`
+	SubmittedAt   time.Time      `json:"submittedAt"`
+	ResubmitCount int            `json:"resubmitCount"`
+}
+
+// addressNonce is the next nonce to hand out for a single from-address,
+// guarded by its own lock so unrelated addresses never contend.
+type addressNonce struct {
+	next  uint64
+	ready bool
+	lock  sync.Mutex
+}
+
+// NonceManager hands out sequential nonces per from-address under a
+// per-address lock and tracks in-flight transactions so stuck ones can
+// be bumped and rebroadcast, recovering the job the txpool of a full
+// node would otherwise do for you.
+type NonceManager struct {
+	client      *Client
+	journalPath string
+
+	addresses map[common.Address]*addressNonce
+	addrLock  sync.Mutex
+
+	pending     map[common.Hash]*pendingTx
+	pendingLock sync.Mutex
+}
+
+func newNonceManager(client *Client, journalPath string) (*NonceManager, error) {
+	nm := &NonceManager{
+		client:      client,
+		journalPath: journalPath,
+		addresses:   make(map[common.Address]*addressNonce),
+		pending:     make(map[common.Hash]*pendingTx),
+	}
+
+	if err := nm.loadJournal(); err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	return nm, nil
+}
+
+// NextNonce returns the next nonce to use for address, seeding from
+// eth_getTransactionCount (pending) the first time address is seen.
+func (nm *NonceManager) NextNonce(ctx context.Context, address common.Address) (uint64, error) {
+	nm.addrLock.Lock()
+	entry, found := nm.addresses[address]
+	if !found {
+		entry = &addressNonce{}
+		nm.addresses[address] = entry
+	}
+	nm.addrLock.Unlock()
+
+	entry.lock.Lock()
+	defer entry.lock.Unlock()
+
+	if !entry.ready {
+		pendingNonce, err := nm.client.PendingNonceAt(ctx, address)
+		if err != nil {
+			return 0, err
+		}
+
+		// Runs in its own goroutine: it makes an RPC call and an unbounded
+		// number of notifier sends, and must never hold up NextNonce - every
+		// other send from address blocks on entry.lock until this returns.
+		go nm.detectNonceGaps(context.Background(), address, pendingNonce)
+
+		entry.next = pendingNonce
+		entry.ready = true
+	}
+
+	nonce := entry.next
+	entry.next++
+	return nonce, nil
+}
+
+// detectNonceGaps reconciles the journal against the chain the first time
+// address's nonce sequence is established, which happens on every process
+// start. A nonce between the last confirmed one and pendingNonce that isn't
+// covered by a journaled pendingTx is ambiguous: it may have been broadcast
+// by a prior process instance and then lost before Track could record it
+// (in which case it's still sitting in the node's mempool, nothing is
+// actually wrong, and Watch will simply never resubmit it), or the instance
+// may have crashed before broadcasting it at all, in which case it's a true
+// gap permanently stalling every higher nonce behind it. Those two cases
+// aren't distinguishable from here without risking clobbering a real,
+// still-pending transfer with a self-transfer replacement, so this only
+// logs and emits a notifier event for an operator to investigate; it does
+// not fill the gap. Cancel (and /tx/cancel) only replaces journaled
+// transactions, so it cannot fill such a nonce either.
+func (nm *NonceManager) detectNonceGaps(ctx context.Context, address common.Address, pendingNonce uint64) {
+	confirmed, err := nm.client.NonceAt(ctx, address, nil)
+	if err != nil {
+		log.Errorf("[NonceManager] could not check confirmed nonce for %s, skipping gap check: %s", address.Hex(), err)
+		return
+	}
+
+	nm.pendingLock.Lock()
+	journaled := make(map[uint64]bool)
+	for _, record := range nm.pending {
+		if record.Address == address {
+			journaled[record.Nonce] = true
+		}
+	}
+	nm.pendingLock.Unlock()
+
+	for nonce := confirmed; nonce < pendingNonce; nonce++ {
+		if journaled[nonce] {
+			continue
+		}
+
+		log.Warnf("[NonceManager] %s nonce %d is unaccounted for (broadcast but never journaled, or never broadcast) - it is not journaled, so /tx/cancel cannot replace it", address.Hex(), nonce)
+
+		event := notifier.NewEthNonceGapEvent(map[string]interface{}{
+			"address": address.Hex(),
+			"nonce":   nonce,
+		})
+		nm.client.noti.EventChan() <- event
+	}
+}
+
+// Track records a freshly-broadcast transaction as in-flight.
+func (nm *NonceManager) Track(address common.Address, tx *types.Transaction) {
+	record := &pendingTx{
+		Address:     address,
+		Nonce:       tx.Nonce(),
+		TxHash:      tx.Hash(),
+		To:          *tx.To(),
+		Value:       tx.Value(),
+		Data:        tx.Data(),
+		GasLimit:    tx.Gas(),
+		GasPrice:    tx.GasPrice(),
+		SubmittedAt: time.Now(),
+	}
+
+	nm.pendingLock.Lock()
+	nm.pending[tx.Hash()] = record
+	nm.pendingLock.Unlock()
+
+	nm.persistJournal()
+}
+
+// Pending returns a snapshot of every in-flight transaction.
+func (nm *NonceManager) Pending() []*pendingTx {
+	nm.pendingLock.Lock()
+	defer nm.pendingLock.Unlock()
+
+	out := make([]*pendingTx, 0, len(nm.pending))
+	for _, record := range nm.pending {
+		out = append(out, record)
+	}
+
+	return out
+}
+
+func (nm *NonceManager) persistJournal() {
+	nm.pendingLock.Lock()
+	defer nm.pendingLock.Unlock()
+
+	file, err := os.OpenFile(nm.journalPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		log.Errorf("[NonceManager] failed to open journal: %s", err)
+		return
+	}
+	defer file.Close()
+
+	if err := json.NewEncoder(file).Encode(nm.pending); err != nil {
+		log.Errorf("[NonceManager] failed to write journal: %s", err)
+	}
+}
+
+func (nm *NonceManager) loadJournal() error {
+	file, err := os.Open(nm.journalPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return json.NewDecoder(file).Decode(&nm.pending)
+}
+
+// Watch polls outstanding transactions for receipts on a ticker, bumping
+// gas price and re-broadcasting anything stuck past ResubmitThreshold.
+// It never returns; call it in its own goroutine.
+func (nm *NonceManager) Watch() {
+	ticker := time.NewTicker(NonceManagerPollInterval)
+	for range ticker.C {
+		nm.poll()
+	}
+}
+
+func (nm *NonceManager) poll() {
+	for _, record := range nm.Pending() {
+		receipt, err := nm.client.TransactionReceipt(context.Background(), record.TxHash)
+		if err == nil && receipt != nil {
+			nm.confirm(record)
+			continue
+		}
+
+		if time.Since(record.SubmittedAt) > ResubmitThreshold {
+			if err := nm.resubmit(record); err != nil {
+				log.Errorf("[NonceManager] resubmit %s failed: %s", record.TxHash.Hex(), err)
+			}
+		}
+	}
+}
+
+func (nm *NonceManager) confirm(record *pendingTx) {
+	nm.pendingLock.Lock()
+	delete(nm.pending, record.TxHash)
+	nm.pendingLock.Unlock()
+	nm.persistJournal()
+
+	event := notifier.NewEthTxConfirmedEvent(map[string]interface{}{
+		"address": record.Address.Hex(),
+		"txHash":  record.TxHash.Hex(),
+		"nonce":   record.Nonce,
+	})
+	nm.client.noti.EventChan() <- event
+}
+
+// resubmit re-signs record's original transaction - same to/value/data/gas
+// limit, same nonce - at a bumped gas price and rebroadcasts it, replacing
+// the tracked hash. It must produce the same effect as the original send,
+// or a stuck transfer would silently turn into a no-op.
+func (nm *NonceManager) resubmit(record *pendingTx) error {
+	bumpedGasPrice := bumpGasPrice(record.GasPrice, GasBumpPercent)
+
+	chainID, err := nm.client.NetworkID(context.Background())
+	if err != nil {
+		return err
+	}
+
+	tx := types.NewTransaction(record.Nonce, record.To, record.Value, record.GasLimit, bumpedGasPrice, record.Data)
+	signedTx, err := nm.client.manager.SignTx(record.Address, tx, chainID)
+	if err != nil {
+		return err
+	}
+
+	if err := nm.client.SendTransaction(context.Background(), signedTx); err != nil {
+		return err
+	}
+
+	oldHash := record.TxHash
+
+	nm.pendingLock.Lock()
+	delete(nm.pending, oldHash)
+	record.TxHash = signedTx.Hash()
+	record.GasPrice = bumpedGasPrice
+	record.SubmittedAt = time.Now()
+	record.ResubmitCount++
+	nm.pending[record.TxHash] = record
+	nm.pendingLock.Unlock()
+	nm.persistJournal()
+
+	event := notifier.NewEthTxReplacedEvent(map[string]interface{}{
+		"address":   record.Address.Hex(),
+		"oldTxHash": oldHash.Hex(),
+		"newTxHash": record.TxHash.Hex(),
+		"gasPrice":  bumpedGasPrice.String(),
+	})
+	nm.client.noti.EventChan() <- event
+
+	return nil
+}
+
+// Cancel drops a pending transaction by replacing it with a zero-value
+// self-send at the same nonce and a bumped gas price, which a miner will
+// include in place of the original. Unlike resubmit, this intentionally
+// changes what the transaction does, so it never reuses resubmit's
+// re-sign-as-is path.
+func (nm *NonceManager) Cancel(address common.Address, nonce uint64) (common.Hash, error) {
+	nm.pendingLock.Lock()
+	var record *pendingTx
+	for _, candidate := range nm.pending {
+		if candidate.Address == address && candidate.Nonce == nonce {
+			record = candidate
+			break
+		}
+	}
+	nm.pendingLock.Unlock()
+
+	if record == nil {
+		return common.Hash{}, ErrTxNotPending
+	}
+
+	bumpedGasPrice := bumpGasPrice(record.GasPrice, GasBumpPercent)
+
+	signedTx, err := nm.client.signSelfTransfer(record.Address, record.Nonce, bumpedGasPrice)
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	if err := nm.client.SendTransaction(context.Background(), signedTx); err != nil {
+		return common.Hash{}, err
+	}
+
+	oldHash := record.TxHash
+
+	nm.pendingLock.Lock()
+	delete(nm.pending, oldHash)
+	record.TxHash = signedTx.Hash()
+	record.To = address
+	record.Value = big.NewInt(0)
+	record.Data = []byte{}
+	record.GasLimit = 21000
+	record.GasPrice = bumpedGasPrice
+	record.SubmittedAt = time.Now()
+	record.ResubmitCount++
+	nm.pending[record.TxHash] = record
+	nm.pendingLock.Unlock()
+	nm.persistJournal()
+
+	event := notifier.NewEthTxDroppedEvent(map[string]interface{}{
+		"address": address.Hex(),
+		"nonce":   nonce,
+	})
+	nm.client.noti.EventChan() <- event
+
+	return record.TxHash, nil
+}
+
+// bumpGasPrice increases gasPrice by a flat percentage for resubmission.
+// This is legacy-style pricing only: on a chain that's moved to EIP-1559,
+// a flat bump off the last GasPrice can still land below the current base
+// fee and be rejected as underpriced rather than simply slow. Supporting
+// EIP-1559 properly means switching every tx-construction site (resubmit,
+// sendFromAddress, sendTokenFromAddress, and signSelfTransfer, which Cancel
+// uses) from types.NewTransaction to
+// types.DynamicFeeTx and bumping GasTipCap/GasFeeCap instead - a wider
+// migration than this fix, and left as a known limitation for now.
+func bumpGasPrice(gasPrice *big.Int, percent int64) *big.Int {
+	bump := new(big.Int).Mul(gasPrice, big.NewInt(percent))
+	bump.Div(bump, big.NewInt(100))
+	return new(big.Int).Add(gasPrice, bump)
+}
@@ -0,0 +1,324 @@
+package eth
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cmingxu/wallet-keeper/notifier"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	log "github.com/sirupsen/logrus"
+)
+
+// transferEventTopic is keccak256("Transfer(address,address,uint256)"),
+// the topic every ERC-20 Transfer log is indexed under.
+var transferEventTopic = common.HexToHash("0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef")
+
+// isWebsocketHost reports whether host is reachable over ws(s), which is
+// required for rpc.Client.Subscribe to work - go-ethereum's HTTP
+// transport doesn't support subscriptions at all.
+func isWebsocketHost(host string) bool {
+	return strings.HasPrefix(host, "ws://") || strings.HasPrefix(host, "wss://")
+}
+
+// subscriptionStatus is a point-in-time snapshot of one active subscription.
+type subscriptionStatus struct {
+	ID          string    `json:"id"`
+	LastEventAt time.Time `json:"lastEventAt"`
+}
+
+// SubscriptionManager drives balance updates off live newHeads/logs
+// subscriptions when the node speaks websocket, replacing
+// accountBalanceWatcher's 20s polling loop. When only an HTTP endpoint
+// is available it falls back to that loop instead.
+type SubscriptionManager struct {
+	client *Client
+
+	// websocket records whether Start subscribed live at all, so Refresh
+	// knows not to try resubscribing a polling fallback.
+	websocket bool
+
+	// subscribeLogs needs two separate filters - log filter topics are
+	// ANDed across positions, so one filter can't mean "from one of ours,
+	// or to one of ours".
+	logsLock    sync.Mutex
+	logsSubFrom *rpc.ClientSubscription
+	logsSubTo   *rpc.ClientSubscription
+
+	statusLock sync.Mutex
+	status     map[string]*subscriptionStatus
+}
+
+func newSubscriptionManager(client *Client) *SubscriptionManager {
+	return &SubscriptionManager{
+		client: client,
+		status: make(map[string]*subscriptionStatus),
+	}
+}
+
+// Start subscribes to newHeads and ERC-20 Transfer logs if host is a
+// websocket endpoint, otherwise it falls back to the ticker loop.
+func (sm *SubscriptionManager) Start(host string) {
+	if !isWebsocketHost(host) {
+		log.Info("[SubscriptionManager] host is not a websocket endpoint, falling back to polling")
+		go sm.client.accountBalanceWatcher()
+		return
+	}
+
+	if err := sm.subscribeHeads(); err != nil {
+		log.Errorf("[SubscriptionManager] newHeads subscription failed, falling back to polling: %s", err)
+		go sm.client.accountBalanceWatcher()
+		return
+	}
+
+	// Only now are we actually live, so Refresh knows it's safe to
+	// resubscribe rather than leaving the polling fallback it never fell
+	// back to running alongside a logs subscription.
+	sm.websocket = true
+
+	if err := sm.subscribeLogs(); err != nil {
+		log.Errorf("[SubscriptionManager] logs subscription failed: %s", err)
+	}
+}
+
+func (sm *SubscriptionManager) subscribeHeads() error {
+	ch := make(chan *types.Header)
+	sub, err := sm.client.ethRpcClient.Subscribe(context.Background(), "eth", ch, "newHeads")
+	if err != nil {
+		return err
+	}
+
+	sm.track("newHeads")
+
+	go func() {
+		for {
+			select {
+			case err := <-sub.Err():
+				log.Errorf("[SubscriptionManager] newHeads subscription error: %s", err)
+				return
+			case <-ch:
+				sm.onHead()
+			}
+		}
+	}()
+
+	return nil
+}
+
+// onHead recomputes every account's native balance, the same check
+// accountBalanceWatcher's ticker used to run every 20s, but in response
+// to an actual new block.
+func (sm *SubscriptionManager) onHead() {
+	sm.touch("newHeads")
+
+	client := sm.client
+	client.accountLock.Lock()
+	addressesByAccount := make(map[string][]string, len(client.accountMap))
+	for account, record := range client.accountMap {
+		// copied while still holding the lock: record.Addresses can be
+		// reassigned by a concurrent GetNewAddress's append.
+		addressesByAccount[account] = append([]string(nil), record.Addresses...)
+	}
+	client.accountLock.Unlock()
+
+	for account, addresses := range addressesByAccount {
+		balance, found := client.accountBalanceMap[account]
+		if !found {
+			continue
+		}
+
+		newBalance, err := client.aggregateBalance(addresses)
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+
+		if balance == newBalance {
+			continue
+		}
+
+		event := notifier.NewEthBalanceChangeEvent(map[string]interface{}{
+			"account":    account,
+			"addresses":  addresses,
+			"newBalance": newBalance,
+			"balance":    balance,
+		})
+		client.noti.EventChan() <- event
+
+		client.accountBalanceLock.Lock()
+		client.accountBalanceMap[account] = newBalance
+		client.accountBalanceLock.Unlock()
+	}
+}
+
+// subscribeLogs subscribes to Transfer(address,address,uint256) logs with
+// every address tracked as of right now. Filter topics are ANDed across
+// positions - a single filter with the tracked set in both topics[1] and
+// topics[2] would only match transfers between two of our own addresses,
+// missing an ordinary incoming deposit from an untracked counterparty. So
+// this opens two filters instead: one constraining topics[1] (from) only,
+// one constraining topics[2] (to) only. The address list is a point-in-time
+// snapshot - call Refresh after accounts gain or lose addresses so the
+// live filters don't go stale.
+func (sm *SubscriptionManager) subscribeLogs() error {
+	client := sm.client
+	addresses := client.trackedAddresses()
+
+	fromSub, err := sm.subscribeLogFilter(map[string]interface{}{
+		"topics": [][]common.Hash{{transferEventTopic}, addresses},
+	})
+	if err != nil {
+		return err
+	}
+
+	toSub, err := sm.subscribeLogFilter(map[string]interface{}{
+		"topics": [][]common.Hash{{transferEventTopic}, nil, addresses},
+	})
+	if err != nil {
+		fromSub.Unsubscribe()
+		return err
+	}
+
+	sm.logsLock.Lock()
+	sm.logsSubFrom = fromSub
+	sm.logsSubTo = toSub
+	sm.logsLock.Unlock()
+
+	sm.track("logs")
+
+	return nil
+}
+
+func (sm *SubscriptionManager) subscribeLogFilter(filter map[string]interface{}) (*rpc.ClientSubscription, error) {
+	client := sm.client
+
+	ch := make(chan types.Log)
+	sub, err := client.ethRpcClient.Subscribe(context.Background(), "eth", ch, "logs", filter)
+	if err != nil {
+		return nil, err
+	}
+
+	go func() {
+		for {
+			select {
+			case err := <-sub.Err():
+				log.Errorf("[SubscriptionManager] logs subscription error: %s", err)
+				return
+			case vLog := <-ch:
+				sm.onTransferLog(vLog)
+			}
+		}
+	}()
+
+	return sub, nil
+}
+
+// Refresh tears down the current logs subscriptions and resubscribes with
+// the latest tracked address set. Call it whenever an account gains or
+// loses an address (new account, new derived address, confirmed hardware
+// association), or that address stays invisible to the live filters until
+// the process restarts. A no-op when running off the polling fallback.
+func (sm *SubscriptionManager) Refresh() {
+	if !sm.websocket {
+		return
+	}
+
+	sm.logsLock.Lock()
+	if sm.logsSubFrom != nil {
+		sm.logsSubFrom.Unsubscribe()
+		sm.logsSubFrom = nil
+	}
+	if sm.logsSubTo != nil {
+		sm.logsSubTo.Unsubscribe()
+		sm.logsSubTo = nil
+	}
+	sm.logsLock.Unlock()
+
+	if err := sm.subscribeLogs(); err != nil {
+		log.Errorf("[SubscriptionManager] failed to refresh logs subscription: %s", err)
+	}
+}
+
+func (sm *SubscriptionManager) onTransferLog(vLog types.Log) {
+	sm.touch("logs")
+
+	if len(vLog.Topics) < 3 {
+		return
+	}
+
+	client := sm.client
+	token := client.tokenBySymbolAddress(vLog.Address)
+	if token == nil {
+		return
+	}
+
+	for _, topic := range []common.Hash{vLog.Topics[1], vLog.Topics[2]} {
+		address := common.BytesToAddress(topic.Bytes())
+		account, record := client.accountOwning(address.Hex())
+		if record == nil {
+			continue
+		}
+
+		newBalance, err := client.aggregateTokenBalance(*token, record.Addresses)
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+
+		key := account + ":" + token.Symbol
+		client.tokenBalanceLock.Lock()
+		balance := client.tokenBalanceMap[key]
+		client.tokenBalanceMap[key] = newBalance
+		client.tokenBalanceLock.Unlock()
+
+		// A transfer between two of our own tracked addresses matches both
+		// the from-filter and to-filter subscriptions, so the same log can
+		// reach onTransferLog twice. Skip the event on the no-op delivery
+		// rather than reporting the same balance change twice.
+		if balance == newBalance {
+			continue
+		}
+
+		event := notifier.NewEthTokenBalanceChangeEvent(map[string]interface{}{
+			"account":    account,
+			"symbol":     token.Symbol,
+			"newBalance": newBalance,
+			"balance":    balance,
+		})
+		client.noti.EventChan() <- event
+	}
+}
+
+func (sm *SubscriptionManager) track(name string) {
+	sm.statusLock.Lock()
+	defer sm.statusLock.Unlock()
+
+	sm.status[name] = &subscriptionStatus{ID: name, LastEventAt: time.Now()}
+}
+
+func (sm *SubscriptionManager) touch(name string) {
+	sm.statusLock.Lock()
+	defer sm.statusLock.Unlock()
+
+	if status, found := sm.status[name]; found {
+		status.LastEventAt = time.Now()
+	}
+}
+
+// Status returns a snapshot of every active subscription's id and last event time.
+func (sm *SubscriptionManager) Status() map[string]*subscriptionStatus {
+	sm.statusLock.Lock()
+	defer sm.statusLock.Unlock()
+
+	out := make(map[string]*subscriptionStatus, len(sm.status))
+	for name, status := range sm.status {
+		copyStatus := *status
+		out[name] = &copyStatus
+	}
+
+	return out
+}
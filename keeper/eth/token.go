@@ -0,0 +1,314 @@
+package eth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"os"
+
+	"github.com/cmingxu/wallet-keeper/keeper"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// ERC-20 method selectors: the first four bytes of keccak256(signature).
+var (
+	selectorBalanceOf = hexutil.MustDecode("0x70a08231") // balanceOf(address)
+	selectorTransfer  = hexutil.MustDecode("0xa9059cbb") // transfer(address,uint256)
+	selectorDecimals  = hexutil.MustDecode("0x313ce567") // decimals()
+)
+
+// TokenTransferGasLimit is the gas limit used for ERC-20 transfer calls;
+// 60k comfortably covers the ~45-51k a standard OpenZeppelin-style
+// transfer actually burns.
+const TokenTransferGasLimit = uint64(60000)
+
+var ErrTokenNotFound = errors.New("token not registered")
+
+// Token is a single registered ERC-20 contract.
+type Token struct {
+	Symbol   string         `json:"symbol"`
+	Address  common.Address `json:"address"`
+	Decimals uint8          `json:"decimals"`
+}
+
+// RegisterToken looks up symbol's decimals() on-chain and adds it to the
+// token registry, persisting it to the JSON config file alongside
+// client.accountFilePath.
+func (client *Client) RegisterToken(symbol, hexAddress string) (Token, error) {
+	if !common.IsHexAddress(hexAddress) {
+		return Token{}, ErrInvalidAddress
+	}
+	address := common.HexToAddress(hexAddress)
+
+	raw, err := client.ethCall(address, selectorDecimals)
+	if err != nil {
+		return Token{}, err
+	}
+
+	token := Token{
+		Symbol:   symbol,
+		Address:  address,
+		Decimals: uint8(new(big.Int).SetBytes(raw).Uint64()),
+	}
+
+	client.tokensLock.Lock()
+	client.tokens[symbol] = token
+	client.tokensLock.Unlock()
+
+	if err := client.persistTokens(); err != nil {
+		return Token{}, err
+	}
+
+	return token, nil
+}
+
+// ListTokens returns every registered token.
+func (client *Client) ListTokens() []Token {
+	client.tokensLock.Lock()
+	defer client.tokensLock.Unlock()
+
+	out := make([]Token, 0, len(client.tokens))
+	for _, token := range client.tokens {
+		out = append(out, token)
+	}
+
+	return out
+}
+
+// GetTokenBalance sums symbol's balance across every address account owns.
+func (client *Client) GetTokenBalance(account, symbol string) (float64, error) {
+	client.tokensLock.Lock()
+	token, found := client.tokens[symbol]
+	client.tokensLock.Unlock()
+
+	if !found {
+		return 0, ErrTokenNotFound
+	}
+
+	addresses, err := client.GetAddressesByAccount(account)
+	if err != nil {
+		return 0, err
+	}
+
+	return client.aggregateTokenBalance(token, addresses)
+}
+
+// ListTokenBalances returns account's balance of every registered token, keyed by symbol.
+func (client *Client) ListTokenBalances(account string) (map[string]float64, error) {
+	addresses, err := client.GetAddressesByAccount(account)
+	if err != nil {
+		return nil, err
+	}
+
+	balances := make(map[string]float64)
+	for _, token := range client.ListTokens() {
+		balance, err := client.aggregateTokenBalance(token, addresses)
+		if err != nil {
+			return nil, err
+		}
+
+		balances[token.Symbol] = balance
+	}
+
+	return balances, nil
+}
+
+// SendTokenFrom transfers amount of symbol to toHexAddress, funded from
+// the first of account's addresses holding enough of the token.
+func (client *Client) SendTokenFrom(account, toHexAddress, symbol string, amount float64) error {
+	client.tokensLock.Lock()
+	token, found := client.tokens[symbol]
+	client.tokensLock.Unlock()
+
+	if !found {
+		return ErrTokenNotFound
+	}
+
+	if !common.IsHexAddress(toHexAddress) {
+		return ErrInvalidAddress
+	}
+	toAddress := common.HexToAddress(toHexAddress)
+
+	client.accountLock.Lock()
+	record, found := client.accountMap[account]
+	client.accountLock.Unlock()
+
+	if !found {
+		return keeper.ErrAccountNotFound
+	}
+
+	if record.HDManaged && !client.unlocked.isUnlocked(account) {
+		return ErrAccountLocked
+	}
+
+	addresses := record.Addresses
+
+	rawAmount := floatToTokenAmount(amount, token.Decimals)
+
+	for _, hexFromAddress := range addresses {
+		fromAddress := common.HexToAddress(hexFromAddress)
+
+		rawBalance, err := client.tokenBalanceOf(token, fromAddress)
+		if err != nil {
+			return err
+		}
+
+		if rawBalance.Cmp(rawAmount) < 0 {
+			continue
+		}
+
+		return client.sendTokenFromAddress(fromAddress, toAddress, token, rawAmount)
+	}
+
+	return ErrInsufficientFunds
+}
+
+func (client *Client) sendTokenFromAddress(fromAddress, toAddress common.Address, token Token, rawAmount *big.Int) error {
+	nonce, err := client.nonceManager.NextNonce(context.Background(), fromAddress)
+	if err != nil {
+		return err
+	}
+
+	gasPrice, err := client.SuggestGasPrice(context.Background())
+	if err != nil {
+		return err
+	}
+
+	chainID, err := client.NetworkID(context.Background())
+	if err != nil {
+		return err
+	}
+
+	data := encodeTransfer(toAddress, rawAmount)
+	tx := types.NewTransaction(nonce, token.Address, big.NewInt(0), TokenTransferGasLimit, gasPrice, data)
+
+	signedTx, err := client.manager.SignTx(fromAddress, tx, chainID)
+	if err != nil {
+		return err
+	}
+
+	if err := client.SendTransaction(context.Background(), signedTx); err != nil {
+		return err
+	}
+
+	client.nonceManager.Track(fromAddress, signedTx)
+	return nil
+}
+
+func (client *Client) aggregateTokenBalance(token Token, addresses []string) (float64, error) {
+	var total float64
+	for _, hexAddress := range addresses {
+		raw, err := client.tokenBalanceOf(token, common.HexToAddress(hexAddress))
+		if err != nil {
+			return total, err
+		}
+
+		total += tokenAmountToFloat(raw, token.Decimals)
+	}
+
+	return total, nil
+}
+
+func (client *Client) tokenBalanceOf(token Token, address common.Address) (*big.Int, error) {
+	raw, err := client.ethCall(token.Address, encodeBalanceOf(address))
+	if err != nil {
+		return nil, err
+	}
+
+	return new(big.Int).SetBytes(raw), nil
+}
+
+// ethCall performs a read-only eth_call against to with the given ABI-encoded data.
+func (client *Client) ethCall(to common.Address, data []byte) ([]byte, error) {
+	callMsg := map[string]interface{}{
+		"to":   to,
+		"data": hexutil.Encode(data),
+	}
+
+	var result hexutil.Bytes
+	err := client.ethRpcClient.CallContext(context.Background(), &result, "eth_call", callMsg, "latest")
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func encodeBalanceOf(address common.Address) []byte {
+	data := make([]byte, 0, 36)
+	data = append(data, selectorBalanceOf...)
+	data = append(data, common.LeftPadBytes(address.Bytes(), 32)...)
+	return data
+}
+
+func encodeTransfer(to common.Address, amount *big.Int) []byte {
+	data := make([]byte, 0, 68)
+	data = append(data, selectorTransfer...)
+	data = append(data, common.LeftPadBytes(to.Bytes(), 32)...)
+	data = append(data, common.LeftPadBytes(amount.Bytes(), 32)...)
+	return data
+}
+
+// tokenAmountToFloat converts a raw on-chain integer balance into a
+// human-scale float given the token's decimals, mirroring weiToEther.
+// float64 can't represent every such value exactly, so two aggregates that
+// are mathematically equal (e.g. recomputed after a transfer between two of
+// our own addresses) can differ by a sub-epsilon rounding error; callers
+// comparing old/new balances for a change should keep that in mind rather
+// than assuming bit-exact equality.
+func tokenAmountToFloat(raw *big.Int, decimals uint8) float64 {
+	divisor := new(big.Float).SetFloat64(1)
+	ten := big.NewFloat(10)
+	for i := uint8(0); i < decimals; i++ {
+		divisor.Mul(divisor, ten)
+	}
+
+	value := new(big.Float).Quo(new(big.Float).SetInt(raw), divisor)
+	f, _ := value.Float64()
+	return f
+}
+
+// floatToTokenAmount converts a human-scale float amount into the raw
+// on-chain integer given the token's decimals, mirroring etherToWei.
+func floatToTokenAmount(amount float64, decimals uint8) *big.Int {
+	multiplier := new(big.Float).SetFloat64(1)
+	ten := big.NewFloat(10)
+	for i := uint8(0); i < decimals; i++ {
+		multiplier.Mul(multiplier, ten)
+	}
+
+	scaled := new(big.Float).Mul(big.NewFloat(amount), multiplier)
+	raw, _ := scaled.Int(nil)
+	return raw
+}
+
+func (client *Client) persistTokens() error {
+	file, err := os.OpenFile(client.tokenFilePath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	client.tokensLock.Lock()
+	defer client.tokensLock.Unlock()
+
+	return json.NewEncoder(file).Encode(client.tokens)
+}
+
+func (client *Client) loadTokens() error {
+	client.tokens = make(map[string]Token)
+
+	file, err := os.Open(client.tokenFilePath)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return json.NewDecoder(file).Decode(&client.tokens)
+}
@@ -0,0 +1,104 @@
+package eth
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrAccountLocked is returned by SendFrom/SendTokenFrom when account
+// hasn't been unlocked, or its unlock has expired.
+var ErrAccountLocked = errors.New("account is locked")
+
+// ErrWrongPassphrase is returned by UnlockAccount when passphrase doesn't
+// match the one the account was created with.
+var ErrWrongPassphrase = errors.New("wrong passphrase")
+
+// DefaultUnlockTimeout bounds how long UnlockAccount grants signing
+// access for when the caller doesn't specify one, matching go-ethereum's
+// keystore.TimedUnlock default.
+var DefaultUnlockTimeout = time.Minute * 5
+
+// unlockCache tracks which accounts currently have signing access, keyed by
+// keeper account name rather than address since one account can own several
+// HD addresses. Expiry and manual locking both run through onExpire, which
+// the owning Client wires up to actually evict the derived private keys from
+// hdWalletBackend - this cache gates real key material, not just an
+// API-level flag.
+type unlockCache struct {
+	expiresAt map[string]time.Time
+	onExpire  func(account string)
+	lock      sync.Mutex
+}
+
+func newUnlockCache(onExpire func(account string)) *unlockCache {
+	return &unlockCache{expiresAt: make(map[string]time.Time), onExpire: onExpire}
+}
+
+func (c *unlockCache) unlock(account string, timeout time.Duration) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.expiresAt[account] = time.Now().Add(timeout)
+}
+
+func (c *unlockCache) isUnlocked(account string) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	expiry, found := c.expiresAt[account]
+	return found && time.Now().Before(expiry)
+}
+
+// lockAccount revokes account's signing access and, if it was unlocked,
+// evicts its derived key material via onExpire.
+func (c *unlockCache) lockAccount(account string) {
+	c.lock.Lock()
+	_, found := c.expiresAt[account]
+	delete(c.expiresAt, account)
+	c.lock.Unlock()
+
+	if found && c.onExpire != nil {
+		c.onExpire(account)
+	}
+}
+
+// evictExpired periodically sweeps out unlocks past their expiry, evicting
+// each one's key material via onExpire. It never returns; call it in its
+// own goroutine.
+func (c *unlockCache) evictExpired() {
+	ticker := time.NewTicker(time.Second * 10)
+	for range ticker.C {
+		c.lock.Lock()
+		now := time.Now()
+		var expired []string
+		for account, expiry := range c.expiresAt {
+			if now.After(expiry) {
+				expired = append(expired, account)
+				delete(c.expiresAt, account)
+			}
+		}
+		c.lock.Unlock()
+
+		if c.onExpire == nil {
+			continue
+		}
+
+		for _, account := range expired {
+			c.onExpire(account)
+		}
+	}
+}
+
+// generatePassphrase returns a random hex-encoded passphrase for
+// CreateAccount to hand back when the caller doesn't supply one.
+func generatePassphrase() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(buf), nil
+}
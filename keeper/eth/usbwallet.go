@@ -0,0 +1,131 @@
+package eth
+
+import (
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/usbwallet"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// DefaultLedgerBaseDerivationPath is the BIP-44 path Ledger's Ethereum
+// app derives its first address from, matching go-ethereum's default.
+var DefaultLedgerBaseDerivationPath = accounts.DefaultBaseDerivationPath
+
+// ledgerBackend adapts go-ethereum's usbwallet.Hub, which talks to
+// Ledger (and Trezor) devices over USB, to the WalletBackend interface.
+// Unlike keystoreBackend, it never holds key material itself - every
+// signature is produced on the device and the user must confirm it there.
+type ledgerBackend struct {
+	hub *usbwallet.Hub
+
+	// derived tracks addresses we've asked the device to expose, so
+	// Accounts() can report them without needing the device attached.
+	derived map[common.Address]accounts.Wallet
+	lock    sync.Mutex
+}
+
+func newLedgerBackend() (*ledgerBackend, error) {
+	hub, err := usbwallet.NewLedgerHub()
+	if err != nil {
+		return nil, err
+	}
+
+	return &ledgerBackend{
+		hub:     hub,
+		derived: make(map[common.Address]accounts.Wallet),
+	}, nil
+}
+
+func (b *ledgerBackend) Accounts() []accounts.Account {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	out := make([]accounts.Account, 0, len(b.derived))
+	for addr, wallet := range b.derived {
+		out = append(out, accounts.Account{Address: addr, URL: wallet.URL()})
+	}
+
+	return out
+}
+
+func (b *ledgerBackend) Open(passphrase string) error {
+	for _, wallet := range b.hub.Wallets() {
+		if err := wallet.Open(passphrase); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (b *ledgerBackend) Close() error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	for _, wallet := range b.derived {
+		wallet.Close()
+	}
+
+	return nil
+}
+
+// Derive asks the first connected Ledger to expose the account at path.
+// When pin is true the device is told to remember the derivation so it
+// survives a reconnect.
+func (b *ledgerBackend) Derive(path accounts.DerivationPath, pin bool) (accounts.Account, error) {
+	wallets := b.hub.Wallets()
+	if len(wallets) == 0 {
+		return accounts.Account{}, fmt.Errorf("no ledger device connected")
+	}
+
+	wallet := wallets[0]
+	if err := wallet.Open(""); err != nil {
+		return accounts.Account{}, err
+	}
+
+	account, err := wallet.Derive(path, pin)
+	if err != nil {
+		return accounts.Account{}, err
+	}
+
+	b.lock.Lock()
+	b.derived[account.Address] = wallet
+	b.lock.Unlock()
+
+	return account, nil
+}
+
+// SignChallenge asks the device holding addr to sign challenge as a
+// personal message, blocking until the holder confirms on screen. It backs
+// /wallets/confirm's proof that the holder of addr actually approved being
+// associated with a keeper account, rather than that association being a
+// no-op.
+func (b *ledgerBackend) SignChallenge(addr common.Address, challenge []byte) ([]byte, error) {
+	b.lock.Lock()
+	wallet, found := b.derived[addr]
+	b.lock.Unlock()
+
+	if !found {
+		return nil, ErrBackendNotFound
+	}
+
+	return wallet.SignText(accounts.Account{Address: addr}, challenge)
+}
+
+func (b *ledgerBackend) SignTx(addr common.Address, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	b.lock.Lock()
+	wallet, found := b.derived[addr]
+	b.lock.Unlock()
+
+	if !found {
+		return nil, ErrBackendNotFound
+	}
+
+	// The device will display the transaction and block here until the
+	// holder confirms or rejects it on screen.
+	return wallet.SignTx(accounts.Account{Address: addr}, tx, chainID)
+}
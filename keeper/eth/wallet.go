@@ -0,0 +1,132 @@
+package eth
+
+import (
+	"errors"
+	"math/big"
+	"sync"
+
+	"github.com/cmingxu/wallet-keeper/keeper"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// ErrBackendNotFound is returned when no registered WalletBackend owns
+// the requested address.
+var ErrBackendNotFound = errors.New("no wallet backend owns this address")
+
+// WalletBackend is the common interface implemented by every source of
+// signing keys the Client can route transactions through: the HD wallet,
+// the on-disk keystore and, for hardware-custodied funds, a Ledger reached
+// over USB.
+// It mirrors go-ethereum's accounts.Wallet, trimmed down to the pieces
+// wallet-keeper actually needs.
+type WalletBackend interface {
+	// Accounts returns every address currently available through this backend.
+	Accounts() []accounts.Account
+
+	// Open prepares the backend for use, e.g. opening the USB connection
+	// to a Ledger. passphrase is ignored by backends that don't need one.
+	Open(passphrase string) error
+
+	// Close releases any resources held by the backend.
+	Close() error
+
+	// Derive adds the account at path to the backend and returns it. If
+	// pin is true the backend keeps tracking the account for later signing;
+	// tracked accounts are held in memory and are not kept across restarts.
+	Derive(path accounts.DerivationPath, pin bool) (accounts.Account, error)
+
+	// SignTx signs tx on behalf of addr, which must be owned by this backend.
+	SignTx(addr common.Address, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error)
+}
+
+// keystoreBackend adapts the existing on-disk *keystore.KeyStore to the
+// WalletBackend interface, so it can be registered in the Manager
+// alongside hardware-wallet backends.
+type keystoreBackend struct {
+	store *keystore.KeyStore
+}
+
+func newKeystoreBackend(store *keystore.KeyStore) *keystoreBackend {
+	return &keystoreBackend{store: store}
+}
+
+func (b *keystoreBackend) Accounts() []accounts.Account {
+	return b.store.Accounts()
+}
+
+func (b *keystoreBackend) Open(passphrase string) error {
+	return nil
+}
+
+func (b *keystoreBackend) Close() error {
+	return nil
+}
+
+func (b *keystoreBackend) Derive(path accounts.DerivationPath, pin bool) (accounts.Account, error) {
+	return accounts.Account{}, keeper.ErrNotSupport
+}
+
+func (b *keystoreBackend) SignTx(addr common.Address, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	return b.store.SignTx(accounts.Account{Address: addr}, tx, chainID)
+}
+
+// Manager keeps track of every WalletBackend the Client can sign with and
+// routes requests to whichever one owns a given address, mirroring
+// go-ethereum's accounts.Manager.
+type Manager struct {
+	backends []WalletBackend
+	lock     sync.RWMutex
+}
+
+func NewManager(backends ...WalletBackend) *Manager {
+	return &Manager{backends: backends}
+}
+
+// Backends returns every backend registered with the manager, in
+// registration order.
+func (m *Manager) Backends() []WalletBackend {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	out := make([]WalletBackend, len(m.backends))
+	copy(out, m.backends)
+	return out
+}
+
+// Register adds a backend to the manager.
+func (m *Manager) Register(backend WalletBackend) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.backends = append(m.backends, backend)
+}
+
+// Find returns the backend that owns addr, or ErrBackendNotFound.
+func (m *Manager) Find(addr common.Address) (WalletBackend, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	for _, backend := range m.backends {
+		for _, acc := range backend.Accounts() {
+			if acc.Address == addr {
+				return backend, nil
+			}
+		}
+	}
+
+	return nil, ErrBackendNotFound
+}
+
+// SignTx looks up the backend that owns addr and signs tx with it.
+func (m *Manager) SignTx(addr common.Address, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	backend, err := m.Find(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	return backend.SignTx(addr, tx, chainID)
+}